@@ -11,6 +11,7 @@ const i18n = {
                 verify_now: "Verify Now",
                 label_verify: "Enter Code to Verify",
                 label_steps: "Tolerance Window",
+                label_hotp_steps: "Look-ahead (counter values)",
                 verified: "VERIFIED",
                 invalid: "INVALID CODE",
                 about_title: "About this Project",
@@ -33,7 +34,41 @@ const i18n = {
                 save_account: "Save Account",
                 add_to_dashboard: "Add to Dashboard",
                 theme_light: "Light",
-                theme_dark: "Dark"
+                theme_dark: "Dark",
+                invalid_secret: "Invalid secret. Use a base32 key, a 0x-prefixed hex key, or an otpauth:// URI.",
+                setup_uri: "Setup URI",
+                uri_copied: "URI COPIED",
+                qr_code: "QR Code",
+                scan_qr: "Scan QR Image",
+                qr_not_found: "No QR code found in that image.",
+                qr_unsupported: "This browser cannot read QR images. Paste the secret or otpauth:// URI instead.",
+                generate_secret: "Generate Secret",
+                format_digits: "Digits",
+                format_words: "Words",
+                format_emoji: "Emoji",
+                format_braille: "Braille",
+                format_large: "Large Print",
+                modal_expires: "Expires On (optional)",
+                expired: "EXPIRED",
+                expires_in: "Expires in {n}d",
+                modal_digits: "Code Length",
+                modal_alphabet: "Code Alphabet (optional)",
+                modal_offset: "Time Offset (seconds, kept out of the setup URI/QR)",
+                backup_password: "This backup is encrypted. Enter its password:",
+                backup_wrong_password: "Could not decrypt the backup. Check the password and try again.",
+                export_encrypted: "Export Encrypted",
+                modal_tags: "Tags (comma separated)",
+                search_accounts: "Search by name or tag",
+                hotp_next: "Next Code",
+                invalid_digits: "Code length must be between 6 and 10 digits.",
+                invalid_offset: "Time offset must be a whole number of seconds between -86400 and 86400.",
+                invalid_code_length: "Code length for this account must be between {min} and {max}.",
+                invalid_alphabet: "The code alphabet needs at least 2 characters, with no repeats.",
+                invalid_alphabet_length: "With this alphabet, codes can be at most {n} characters long.",
+                backup_new_password: "Choose a password for the encrypted backup:",
+                backup_confirm_password: "Enter the password again:",
+                backup_password_mismatch: "Passwords do not match. Nothing was exported.",
+                backup_unsupported: "This encrypted backup uses unsupported key settings and was not imported."
             },
             cn: {
                 title: "TOTP 令牌生成器",
@@ -47,6 +82,7 @@ const i18n = {
                 verify_now: "立即验证",
                 label_verify: "输入要验证的代码",
                 label_steps: "容差窗口",
+                label_hotp_steps: "前瞻范围（计数器值）",
                 verified: "验证通过",
                 invalid: "验证码错误",
                 about_title: "关于本项目",
@@ -69,7 +105,41 @@ const i18n = {
                 save_account: "保存帐号",
                 add_to_dashboard: "添加到仪表板",
                 theme_light: "浅色",
-                theme_dark: "深色"
+                theme_dark: "深色",
+                invalid_secret: "密钥无效。请使用 base32 密钥、以 0x 开头的十六进制密钥，或 otpauth:// 链接。",
+                setup_uri: "设置链接",
+                uri_copied: "链接已复制",
+                qr_code: "二维码",
+                scan_qr: "扫描二维码图片",
+                qr_not_found: "图片中未找到二维码。",
+                qr_unsupported: "此浏览器无法读取二维码图片，请直接粘贴密钥或 otpauth:// 链接。",
+                generate_secret: "生成密钥",
+                format_digits: "数字",
+                format_words: "单词",
+                format_emoji: "表情",
+                format_braille: "盲文",
+                format_large: "大字体",
+                modal_expires: "到期日（可选）",
+                expired: "已过期",
+                expires_in: "{n} 天后过期",
+                modal_digits: "代码长度",
+                modal_alphabet: "代码字符集（可选）",
+                modal_offset: "时间偏移（秒，不包含在设置链接/二维码中）",
+                backup_password: "此备份已加密，请输入密码：",
+                backup_wrong_password: "无法解密备份，请检查密码后重试。",
+                export_encrypted: "加密导出",
+                modal_tags: "标签（用逗号分隔）",
+                search_accounts: "按名称或标签搜索",
+                hotp_next: "下一个代码",
+                invalid_digits: "代码长度必须在 6 到 10 位之间。",
+                invalid_offset: "时间偏移必须是 -86400 到 86400 之间的整数秒。",
+                invalid_code_length: "此帐号的代码长度必须在 {min} 到 {max} 之间。",
+                invalid_alphabet: "代码字符集至少需要 2 个字符，且不能重复。",
+                invalid_alphabet_length: "使用此字符集时，代码最长为 {n} 个字符。",
+                backup_new_password: "请为加密备份设置密码：",
+                backup_confirm_password: "请再次输入密码：",
+                backup_password_mismatch: "两次输入的密码不一致，未导出任何内容。",
+                backup_unsupported: "此加密备份的密钥参数不受支持，未导入。"
             }
         };
 
@@ -91,43 +161,578 @@ const i18n = {
             return buf;
         }
 
-        async function generateTOTP(secret, time = Date.now()) {
+        function bufToBase32(buf) {
+            const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567";
+            let bits = "";
+            for (let i = 0; i < buf.length; i++) {
+                bits += buf[i].toString(2).padStart(8, '0');
+            }
+            let out = "";
+            for (let i = 0; i < bits.length; i += 5) {
+                out += alphabet[parseInt(bits.substr(i, 5).padEnd(5, '0'), 2)];
+            }
+            return out;
+        }
+
+        function hexToBuf(hex) {
+            return new Uint8Array((hex.match(/../g) || []).map(h => parseInt(h, 16)));
+        }
+
+        // Clean-up steps applied to pasted secrets, keyed by name.
+        const secretNormalizers = {
+            whitespace: s => s.replace(/\s/g, ''),
+            dashes: s => s.replace(/-/g, ''),
+            uppercase: s => s.toUpperCase()
+        };
+        // Lenient by default. Deployments that want strict RFC 4648 input set
+        // <html data-secret-parsing="strict">, which only accepts canonical uppercase base32.
+        const strictSecretParsing = document.documentElement && document.documentElement.dataset
+            && document.documentElement.dataset.secretParsing === 'strict';
+        const enabledSecretNormalizers = strictSecretParsing ? [] : ['whitespace', 'dashes', 'uppercase'];
+
+        // Same bounds for every source of digits/period: otpauth:// URIs, share links, the modal
+        // and backup imports.
+        const MAX_PERIOD_SECONDS = 3600;
+        const isValidDigits = digits => Number.isInteger(digits) && digits >= 6 && digits <= 10;
+        const isValidPeriod = period => Number.isInteger(period) && period > 0 && period <= MAX_PERIOD_SECONDS;
+        // Steam and Yandex codes have their own lengths rather than 6-10 digits.
+        function isValidCodeLength(digits, truncation) {
+            if (!truncation || !Object.hasOwn(truncationCodeLengths, truncation)) return isValidDigits(digits);
+            const { min, max } = truncationCodeLengths[truncation];
+            return Number.isInteger(digits) && digits >= min && digits <= max;
+        }
+        const MAX_OFFSET_SECONDS = 86400;
+        const isValidOffset = offset => Number.isInteger(offset) && Math.abs(offset) <= MAX_OFFSET_SECONDS;
+
+        // Accepts an otpauth:// URI, base32 or 0x-prefixed hex and returns { secret, name } with a
+        // base32 secret, or null for garbage. See secretNormalizers for what is tolerated.
+        function parseSecretInput(input) {
+            if (typeof input !== 'string') return null;
+            let value = input.trim();
+            let name = '';
+            const params = {};
+
+            if (/^otpauth:\/\//i.test(value)) {
+                try {
+                    const uri = new URL(value);
+                    const label = decodeURIComponent(uri.pathname.replace(/^\/+/, ''));
+                    name = uri.searchParams.get('issuer') || label.split(':')[0].trim();
+                    value = uri.searchParams.get('secret') || '';
+                    const digits = parseInt(uri.searchParams.get('digits'), 10);
+                    const period = parseInt(uri.searchParams.get('period'), 10);
+                    if (isValidDigits(digits)) params.digits = digits;
+                    if (isValidPeriod(period)) params.period = period;
+                    // encoder= is a vendor extension; Steam is the only value apps actually emit
+                    if ((uri.searchParams.get('encoder') || '').toLowerCase() === 'steam') {
+                        params.truncation = 'steam';
+                        params.digits = truncationCodeLengths.steam.max;
+                    }
+                    const algorithm = (uri.searchParams.get('algorithm') || '').toUpperCase();
+                    if (HMAC_ALGORITHMS[algorithm]) params.algorithm = algorithm;
+                    if (uri.hostname.toLowerCase() === 'yaotp') {
+                        // Yandex.Key as exported by Aegis: SHA-256, 8 letters, key derived from a PIN
+                        params.truncation = 'yandex';
+                        params.algorithm = 'SHA256';
+                        params.digits = params.digits || 8;
+                        if (uri.searchParams.get('pin')) params.pin = uri.searchParams.get('pin');
+                    }
+                    if (uri.hostname.toLowerCase() === 'hotp') {
+                        params.type = 'hotp';
+                        params.counter = Math.max(parseInt(uri.searchParams.get('counter'), 10) || 0, 0);
+                    }
+                } catch {
+                    return null;
+                }
+            }
+
+            value = enabledSecretNormalizers.reduce((v, n) => secretNormalizers[n](v), value);
+            // Hex needs the 0x prefix: strings like "deadbeef" are valid base32 too, and guessing
+            // would silently produce the wrong codes
+            if (/^0x[0-9a-f]+$/i.test(value)) {
+                const hex = value.slice(2);
+                if (hex.length % 2 !== 0) return null;
+                return { secret: bufToBase32(hexToBuf(hex)), name, ...params };
+            }
+
+            // Reject lengths no base32 encoder can produce instead of silently dropping bits.
+            // Padding is optional, but when present it has to be complete.
+            const unpadded = value.replace(/=+$/, '');
+            if (!/^[A-Z2-7]+=*$/.test(value) || [1, 3, 6].includes(unpadded.length % 8)) return null;
+            if (value !== unpadded && value.length % 8 !== 0) return null;
+            return { secret: unpadded, name, ...params };
+        }
+
+        // Mints a fresh random base32 secret for enrolling a new account.
+        function generateSecret(bits = 160) {
+            return bufToBase32(crypto.getRandomValues(new Uint8Array(Math.ceil(bits / 8))));
+        }
+
+        // Builds an otpauth:// provisioning URI that authenticator apps can enroll from.
+        function buildKeyURI(issuer, account, secret, opts = {}) {
+            const label = issuer
+                ? `${encodeURIComponent(issuer)}:${encodeURIComponent(account)}`
+                : encodeURIComponent(account);
+            const params = [['secret', secret], ['issuer', issuer]];
+            if (opts.algorithm) params.push(['algorithm', opts.algorithm]);
+            if (opts.digits) params.push(['digits', opts.digits]);
+            if (opts.period) params.push(['period', opts.period]);
+            if (opts.encoder) params.push(['encoder', opts.encoder]);
+            if (opts.type === 'hotp') params.push(['counter', String(opts.counter || 0)]);
+            const query = params
+                .filter(([, v]) => v)
+                .map(([k, v]) => `${k}=${encodeURIComponent(v)}`)
+                .join('&');
+            return `otpauth://${opts.type === 'hotp' ? 'hotp' : 'totp'}/${label}?${query}`;
+        }
+
+        // --- Truncation Strategies ---
+        // Each strategy turns the HMAC output into a displayable code.
+
+        // RFC 4226 dynamic truncation: 31 bits starting at the offset in the last nibble.
+        function truncate31(hmac) {
+            const offset = hmac[hmac.length - 1] & 0x0f;
+            return (
+                ((hmac[offset] & 0x7f) << 24) |
+                ((hmac[offset + 1] & 0xff) << 16) |
+                ((hmac[offset + 2] & 0xff) << 8) |
+                (hmac[offset + 3] & 0xff)
+            );
+        }
+
+        // The same idea widened to 63 bits (8 bytes), as Yandex.Key does, so longer
+        // alphanumeric codes still vary in every character. SHA-1's 20-byte HMAC can't fit
+        // 8 bytes past offset 12, so the offset is clamped there.
+        function truncate63(hmac) {
+            const offset = Math.min(hmac[hmac.length - 1] & 0x0f, hmac.length - 8);
+            let value = BigInt(hmac[offset] & 0x7f);
+            for (let i = 1; i < 8; i++) value = (value << 8n) | BigInt(hmac[offset + i]);
+            return value;
+        }
+
+        const ALPHABET_TRUNCATION_BITS = 63;
+
+        // Longest code whose every character is still driven by the truncated bits;
+        // beyond this the trailing characters would be constant.
+        function maxAlphabetCodeLength(size, bits = ALPHABET_TRUNCATION_BITS) {
+            let length = 0;
+            while (BigInt(size) ** BigInt(length + 1) <= 2n ** BigInt(bits)) length++;
+            return length;
+        }
+
+        function encodeWithAlphabet(value, length, alphabet, bits) {
+            const chars = Array.from(alphabet);
+            const size = BigInt(chars.length);
+            let rest = BigInt(value);
+            // Least significant character first, as Steam Guard does
+            let code = '';
+            for (let i = 0; i < Math.min(length, maxAlphabetCodeLength(chars.length, bits)); i++) {
+                code += chars[Number(rest % size)];
+                rest /= size;
+            }
+            return code;
+        }
+
+        const YANDEX_ALPHABET = 'abcdefghijklmnopqrstuvwxyz';
+
+        const truncationStrategies = {
+            standard: (hmac, { digits }) => (truncate31(hmac) % Math.pow(10, digits)).toString().padStart(digits, '0'),
+            steam: (hmac) => encodeWithAlphabet(truncate31(hmac), 5, '23456789BCDFGHJKMNPQRTVWXY', 31),
+            alphabet: (hmac, { digits, alphabet }) => encodeWithAlphabet(truncate63(hmac), digits, alphabet),
+            // Yandex.Key: 63-bit truncation reduced mod 26^digits, most significant letter first
+            yandex: (hmac, { digits }) => {
+                let rest = truncate63(hmac) % (26n ** BigInt(digits));
+                let code = '';
+                for (let i = 0; i < digits; i++) {
+                    code = YANDEX_ALPHABET[Number(rest % 26n)] + code;
+                    rest /= 26n;
+                }
+                return code;
+            }
+        };
+
+        // Code lengths each fixed-alphabet strategy can render; custom alphabets use
+        // maxAlphabetCodeLength and the standard strategy uses isValidDigits.
+        const truncationCodeLengths = {
+            steam: { min: 5, max: 5 },
+            yandex: { min: 1, max: maxAlphabetCodeLength(YANDEX_ALPHABET.length) }
+        };
+
+        // Yandex.Key signs with SHA-256(PIN || first 16 secret bytes) rather than the raw secret,
+        // dropping a leading zero byte from the digest as the official app does.
+        async function yandexKey(keyBuf, pin) {
+            const pinBytes = new TextEncoder().encode(pin);
+            const input = new Uint8Array(pinBytes.length + 16);
+            input.set(pinBytes);
+            input.set(keyBuf.subarray(0, 16), pinBytes.length);
+            const hash = new Uint8Array(await crypto.subtle.digest("SHA-256", input));
+            return hash[0] === 0 ? hash.subarray(1) : hash;
+        }
+
+        // otpauth:// algorithm names mapped to their SubtleCrypto hash names
+        const HMAC_ALGORITHMS = { SHA1: 'SHA-1', SHA256: 'SHA-256', SHA512: 'SHA-512' };
+
+        // RFC 4226 HOTP for an explicit counter; TOTP below derives the counter from the clock.
+        async function generateHOTP(secret, counter, { digits = 6, alphabet, truncation, algorithm = 'SHA1', pin } = {}) {
             try {
-                const keyBuf = base32ToBuf(secret);
-                const epoch = Math.floor(time / 1000);
-                const counter = Math.floor(epoch / 30);
+                let keyBuf = base32ToBuf(secret);
+                if (truncation === 'yandex' && pin) keyBuf = await yandexKey(keyBuf, pin);
 
                 // Prepare counter as 8-byte big-endian
                 const msg = new Uint8Array(8);
                 let tempCounter = counter;
                 for (let i = 7; i >= 0; i--) {
-                    msg[i] = tempCounter & 0xff;
-                    tempCounter = tempCounter >> 8;
+                    msg[i] = tempCounter % 256;
+                    tempCounter = Math.floor(tempCounter / 256);
                 }
 
                 const cryptoKey = await crypto.subtle.importKey(
                     "raw", keyBuf,
-                    { name: "HMAC", hash: "SHA-1" },
+                    { name: "HMAC", hash: HMAC_ALGORITHMS[algorithm] || "SHA-1" },
                     false, ["sign"]
                 );
 
                 const signature = await crypto.subtle.sign("HMAC", cryptoKey, msg);
                 const hmac = new Uint8Array(signature);
-                const offset = hmac[hmac.length - 1] & 0x0f;
-                const otp = (
-                    ((hmac[offset] & 0x7f) << 24) |
-                    ((hmac[offset + 1] & 0xff) << 16) |
-                    ((hmac[offset + 2] & 0xff) << 8) |
-                    (hmac[offset + 3] & 0xff)
-                ) % 1000000;
-
-                return otp.toString().padStart(6, '0');
+
+                // Names come from stored accounts and scanned URIs, so ignore inherited keys like "toString"
+                const name = truncation || (alphabet ? 'alphabet' : 'standard');
+                const strategy = Object.hasOwn(truncationStrategies, name)
+                    ? truncationStrategies[name]
+                    : truncationStrategies.standard;
+                return strategy(hmac, { digits, alphabet });
             } catch (e) {
-                console.error("TOTP Generation Error:", e);
+                console.error("OTP Generation Error:", e);
                 return null;
             }
         }
 
+        async function generateTOTP(secret, time = Date.now(), { period = 30, ...options } = {}) {
+            const epoch = Math.floor(time / 1000);
+            return generateHOTP(secret, Math.floor(epoch / period), options);
+        }
+
+        // --- QR Code Encoder (byte mode, error correction level M) ---
+
+        const QR_ECC_PER_BLOCK = [-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26,
+            26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28];
+        const QR_NUM_BLOCKS = [-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16,
+            17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49];
+
+        function qrRawModules(ver) {
+            let result = (16 * ver + 128) * ver + 64;
+            if (ver >= 2) {
+                const numAlign = Math.floor(ver / 7) + 2;
+                result -= (25 * numAlign - 10) * numAlign - 55;
+                if (ver >= 7) result -= 36;
+            }
+            return result;
+        }
+
+        function qrDataCodewords(ver) {
+            return Math.floor(qrRawModules(ver) / 8) - QR_ECC_PER_BLOCK[ver] * QR_NUM_BLOCKS[ver];
+        }
+
+        function gfMultiply(x, y) {
+            let z = 0;
+            for (let i = 7; i >= 0; i--) {
+                z = (z << 1) ^ ((z >>> 7) * 0x11d);
+                z ^= ((y >>> i) & 1) * x;
+            }
+            return z;
+        }
+
+        function reedSolomonRemainder(data, degree) {
+            const divisor = new Array(degree - 1).fill(0).concat([1]);
+            let root = 1;
+            for (let i = 0; i < degree; i++) {
+                for (let j = 0; j < divisor.length; j++) {
+                    divisor[j] = gfMultiply(divisor[j], root);
+                    if (j + 1 < divisor.length) divisor[j] ^= divisor[j + 1];
+                }
+                root = gfMultiply(root, 0x02);
+            }
+            const result = new Array(degree).fill(0);
+            for (const b of data) {
+                const factor = b ^ result.shift();
+                result.push(0);
+                divisor.forEach((coef, i) => result[i] ^= gfMultiply(coef, factor));
+            }
+            return result;
+        }
+
+        function qrCodewords(bytes, ver) {
+            const bits = [];
+            const push = (val, len) => {
+                for (let i = len - 1; i >= 0; i--) bits.push((val >>> i) & 1);
+            };
+            push(0x4, 4);
+            push(bytes.length, ver < 10 ? 8 : 16);
+            bytes.forEach(b => push(b, 8));
+            const capacity = qrDataCodewords(ver) * 8;
+            push(0, Math.min(4, capacity - bits.length));
+            push(0, (8 - bits.length % 8) % 8);
+            for (let pad = 0xec; bits.length < capacity; pad ^= 0xec ^ 0x11) push(pad, 8);
+
+            const data = [];
+            for (let i = 0; i < bits.length; i += 8) {
+                data.push(parseInt(bits.slice(i, i + 8).join(''), 2));
+            }
+
+            // Split into blocks, append ECC to each, then interleave
+            const numBlocks = QR_NUM_BLOCKS[ver];
+            const eccLen = QR_ECC_PER_BLOCK[ver];
+            const rawCodewords = Math.floor(qrRawModules(ver) / 8);
+            const numShortBlocks = numBlocks - rawCodewords % numBlocks;
+            const shortBlockLen = Math.floor(rawCodewords / numBlocks);
+            const blocks = [];
+            for (let i = 0, k = 0; i < numBlocks; i++) {
+                const dat = data.slice(k, k + shortBlockLen - eccLen + (i < numShortBlocks ? 0 : 1));
+                k += dat.length;
+                const ecc = reedSolomonRemainder(dat, eccLen);
+                if (i < numShortBlocks) dat.push(0);
+                blocks.push(dat.concat(ecc));
+            }
+            const result = [];
+            for (let i = 0; i < blocks[0].length; i++) {
+                blocks.forEach((block, j) => {
+                    if (i !== shortBlockLen - eccLen || j >= numShortBlocks) result.push(block[i]);
+                });
+            }
+            return result;
+        }
+
+        function qrMatrix(codewords, ver, mask) {
+            const size = ver * 4 + 17;
+            const modules = Array.from({ length: size }, () => new Array(size).fill(false));
+            const isFunction = Array.from({ length: size }, () => new Array(size).fill(false));
+            const setFunction = (x, y, dark) => {
+                modules[y][x] = dark;
+                isFunction[y][x] = true;
+            };
+
+            for (let i = 0; i < size; i++) {
+                setFunction(6, i, i % 2 === 0);
+                setFunction(i, 6, i % 2 === 0);
+            }
+            for (const [cx, cy] of [[3, 3], [size - 4, 3], [3, size - 4]]) {
+                for (let dy = -4; dy <= 4; dy++) {
+                    for (let dx = -4; dx <= 4; dx++) {
+                        const dist = Math.max(Math.abs(dx), Math.abs(dy));
+                        const x = cx + dx, y = cy + dy;
+                        if (x >= 0 && x < size && y >= 0 && y < size) setFunction(x, y, dist !== 2 && dist !== 4);
+                    }
+                }
+            }
+            if (ver > 1) {
+                const numAlign = Math.floor(ver / 7) + 2;
+                const step = Math.floor((ver * 8 + numAlign * 3 + 5) / (numAlign * 4 - 4)) * 2;
+                const positions = [6];
+                for (let pos = size - 7; positions.length < numAlign; pos -= step) positions.splice(1, 0, pos);
+                positions.forEach((ax, i) => positions.forEach((ay, j) => {
+                    if ((i === 0 && j === 0) || (i === 0 && j === numAlign - 1) || (i === numAlign - 1 && j === 0)) return;
+                    for (let dy = -2; dy <= 2; dy++) {
+                        for (let dx = -2; dx <= 2; dx++) {
+                            setFunction(ax + dx, ay + dy, Math.max(Math.abs(dx), Math.abs(dy)) !== 1);
+                        }
+                    }
+                }));
+            }
+
+            // Format bits: level M (00) plus mask, BCH(15,5) protected
+            const format = mask;
+            let rem = format;
+            for (let i = 0; i < 10; i++) rem = (rem << 1) ^ ((rem >>> 9) * 0x537);
+            const formatBits = ((format << 10) | rem) ^ 0x5412;
+            const formatBit = (i) => ((formatBits >>> i) & 1) !== 0;
+            for (let i = 0; i <= 5; i++) setFunction(8, i, formatBit(i));
+            setFunction(8, 7, formatBit(6));
+            setFunction(8, 8, formatBit(7));
+            setFunction(7, 8, formatBit(8));
+            for (let i = 9; i < 15; i++) setFunction(14 - i, 8, formatBit(i));
+            for (let i = 0; i < 8; i++) setFunction(size - 1 - i, 8, formatBit(i));
+            for (let i = 8; i < 15; i++) setFunction(8, size - 15 + i, formatBit(i));
+            setFunction(8, size - 8, true);
+
+            if (ver >= 7) {
+                let vrem = ver;
+                for (let i = 0; i < 12; i++) vrem = (vrem << 1) ^ ((vrem >>> 11) * 0x1f25);
+                const versionBits = (ver << 12) | vrem;
+                for (let i = 0; i < 18; i++) {
+                    const dark = ((versionBits >>> i) & 1) !== 0;
+                    const a = size - 11 + i % 3, b = Math.floor(i / 3);
+                    setFunction(a, b, dark);
+                    setFunction(b, a, dark);
+                }
+            }
+
+            // Zig-zag the codewords through the remaining modules
+            let bit = 0;
+            for (let right = size - 1; right >= 1; right -= 2) {
+                if (right === 6) right = 5;
+                for (let vert = 0; vert < size; vert++) {
+                    for (let j = 0; j < 2; j++) {
+                        const x = right - j;
+                        const y = ((right + 1) & 2) === 0 ? size - 1 - vert : vert;
+                        if (!isFunction[y][x] && bit < codewords.length * 8) {
+                            modules[y][x] = ((codewords[bit >>> 3] >>> (7 - (bit & 7))) & 1) !== 0;
+                            bit++;
+                        }
+                    }
+                }
+            }
+
+            const maskFns = [
+                (x, y) => (x + y) % 2 === 0,
+                (x, y) => y % 2 === 0,
+                (x) => x % 3 === 0,
+                (x, y) => (x + y) % 3 === 0,
+                (x, y) => (Math.floor(x / 3) + Math.floor(y / 2)) % 2 === 0,
+                (x, y) => x * y % 2 + x * y % 3 === 0,
+                (x, y) => (x * y % 2 + x * y % 3) % 2 === 0,
+                (x, y) => ((x + y) % 2 + x * y % 3) % 2 === 0
+            ];
+            for (let y = 0; y < size; y++) {
+                for (let x = 0; x < size; x++) {
+                    if (!isFunction[y][x] && maskFns[mask](x, y)) modules[y][x] = !modules[y][x];
+                }
+            }
+            return modules;
+        }
+
+        // Simplified mask penalty (runs, 2x2 blocks and dark balance); good enough to
+        // steer clear of masks that scanners struggle with.
+        function qrPenalty(modules) {
+            const size = modules.length;
+            let penalty = 0, dark = 0;
+            for (let a = 0; a < size; a++) {
+                let rowRun = 1, colRun = 1;
+                for (let b = 1; b < size; b++) {
+                    rowRun = modules[a][b] === modules[a][b - 1] ? rowRun + 1 : 1;
+                    colRun = modules[b][a] === modules[b - 1][a] ? colRun + 1 : 1;
+                    if (rowRun === 5) penalty += 3; else if (rowRun > 5) penalty++;
+                    if (colRun === 5) penalty += 3; else if (colRun > 5) penalty++;
+                }
+            }
+            for (let y = 0; y < size; y++) {
+                for (let x = 0; x < size; x++) {
+                    if (modules[y][x]) dark++;
+                    if (x < size - 1 && y < size - 1) {
+                        const c = modules[y][x];
+                        if (c === modules[y][x + 1] && c === modules[y + 1][x] && c === modules[y + 1][x + 1]) penalty += 3;
+                    }
+                }
+            }
+            const total = size * size;
+            penalty += (Math.ceil(Math.abs(dark * 20 - total * 10) / total) - 1) * 10;
+            return penalty;
+        }
+
+        // Encodes text into a QR matrix (rows of booleans, true = dark), or null if it is too long.
+        function encodeQR(text) {
+            const bytes = Array.from(new TextEncoder().encode(text));
+            let ver = 1;
+            while (ver <= 40 && bytes.length + (ver < 10 ? 2 : 3) > qrDataCodewords(ver)) ver++;
+            if (ver > 40) return null;
+            const codewords = qrCodewords(bytes, ver);
+            let best = null, bestPenalty = Infinity;
+            for (let mask = 0; mask < 8; mask++) {
+                const modules = qrMatrix(codewords, ver, mask);
+                const penalty = qrPenalty(modules);
+                if (penalty < bestPenalty) {
+                    best = modules;
+                    bestPenalty = penalty;
+                }
+            }
+            return best;
+        }
+
+        function qrToSvg(modules, border = 4) {
+            const size = modules.length + border * 2;
+            let path = '';
+            modules.forEach((row, y) => row.forEach((dark, x) => {
+                if (dark) path += `M${x + border},${y + border}h1v1h-1z`;
+            }));
+            return `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 ${size} ${size}" shape-rendering="crispEdges">` +
+                `<rect width="100%" height="100%" fill="#fff"/><path d="${path}" fill="#000"/></svg>`;
+        }
+
+        // Reads the first QR code in an image file using the browser's BarcodeDetector.
+        // Resolves to the decoded text, or null if the image holds no QR code.
+        async function decodeQRImage(file) {
+            if (!('BarcodeDetector' in window)) throw new Error('unsupported');
+            const bitmap = await createImageBitmap(file);
+            const detector = new window.BarcodeDetector({ formats: ['qr_code'] });
+            const codes = await detector.detect(bitmap);
+            return codes.length > 0 ? codes[0].rawValue : null;
+        }
+
+        // --- Code Display Encoders ---
+
+        // PGP word list: even-position bytes use the two-syllable list, odd-position the three-syllable one.
+        const PGP_EVEN_WORDS = `
+            aardvark absurd accrue acme adrift adult afflict ahead aimless Algol allow alone ammo ancient apple artist
+            assume Athens atlas Aztec baboon backfield backward banjo beaming bedlamp beehive beeswax befriend Belfast berserk billiard
+            bison blackjack blockade blowtorch bluebird bombast bookshelf brackish breadline breakup brickyard briefcase Burbank button buzzard cement
+            chairlift chatter checkup chisel choking chopper Christmas clamshell classic classroom cleanup clockwork cobra commence concert cowbell
+            crackdown cranky crowfoot crucial crumpled crusade cubic dashboard deadbolt deckhand dogsled dragnet drainage dreadful drifter dropper
+            drumbeat drunken Dupont dwelling eating edict egghead eightball endorse endow enlist erase escape exceed eyeglass eyetooth
+            facial fallout flagpole flatfoot flytrap fracture framework freedom frighten gazelle Geiger glitter glucose goggles goldfish gremlin
+            guidance hamlet highchair hockey indoors indulge inverse involve island jawbone keyboard kickoff kiwi klaxon locale lockup
+            merit minnow miser Mohawk mural music necklace Neptune newborn nightbird Oakland obtuse offload optic orca payday
+            peachy pheasant physique playhouse Pluto preclude prefer preshrunk printer prowler pupil puppy python quadrant quiver quota
+            ragtime ratchet rebirth reform regain reindeer rematch repay retouch revenge reward rhythm ribcage ringbolt robust rocker
+            ruffled sailboat sawdust scallion scenic scorecard Scotland seabird select sentence shadow shamrock showgirl skullcap skydive slingshot
+            slowdown snapline snapshot snowcap snowslide solo southward soybean spaniel spearhead spellbind spheroid spigot spindle spyglass stagehand
+            stagnate stairway standard stapler steamship sterling stockman stopwatch stormy sugar surmount suspense sweatband swelter tactics talon
+            tapeworm tempest tiger tissue tonic topmost tracker transit trauma treadmill Trojan trouble tumor tunnel tycoon uncut
+            unearth unwind uproot upset upshot vapor village virus Vulcan waffle wallet watchword wayside willow woodlark Zulu
+        `.trim().split(/\s+/);
+        const PGP_ODD_WORDS = `
+            adroitness adviser aftermath aggregate alkali almighty amulet amusement antenna applicant Apollo armistice article asteroid Atlantic atmosphere
+            autopsy Babylon backwater barbecue belowground bifocals bodyguard bookseller borderline bottomless Bradbury bravado Brazilian breakaway Burlington businessman
+            butterfat Camelot candidate cannonball Capricorn caravan caretaker celebrate cellulose certify chambermaid Cherokee Chicago clergyman coherence combustion
+            commando company component concurrent confidence conformist congregate consensus consulting corporate corrosion councilman crossover crucifix cumbersome customer
+            Dakota decadence December decimal designing detector detergent determine dictator dinosaur direction disable disbelief disruptive distortion document
+            embezzle enchanting enrollment enterprise equation equipment escapade Eskimo everyday examine existence exodus fascinate filament finicky forever
+            fortitude frequency gadgetry Galveston getaway glossary gossamer graduate gravity guitarist hamburger Hamilton handiwork hazardous headwaters hemisphere
+            hesitate hideaway holiness hurricane hydraulic impartial impetus inception indigo inertia infancy inferno informant insincere insurgent integrate
+            intention inventive Istanbul Jamaica Jupiter leprosy letterhead liberty maritime matchmaker maverick Medusa megaton microscope microwave midsummer
+            millionaire miracle misnomer molasses molecule Montana monument mosquito narrative nebula newsletter Norwegian October Ohio onlooker opulent
+            Orlando outfielder Pacific pandemic Pandora paperweight paragon paragraph paramount passenger pedigree Pegasus penetrate perceptive performance pharmacy
+            phonetic photograph pioneer pocketful politeness positive potato processor provincial proximate puberty publisher pyramid quantity racketeer rebellion
+            recipe recover repellent replica reproduce resistor responsive retraction retrieval retrospect revenue revival revolver sandalwood sardonic Saturday
+            savagery scavenger sensation sociable souvenir specialist speculate stethoscope stupendous supportive surrender suspicious sympathy tambourine telephone therapist
+            tobacco tolerance tomorrow torpedo tradition travesty trombonist truncated typewriter ultimate undaunted underfoot unicorn unify universe unravel
+            upcoming vacancy vagabond vertigo Virginia visitor vocalist voyager warranty Waterloo whimsical Wichita Wilmington Wyoming yesteryear Yucatan
+        `.trim().split(/\s+/);
+        // 100 distinct, easily named emoji; each encodes a pair of digits.
+        const CODE_EMOJI = Array.from("🐶🐱🐭🐹🐰🦊🐻🐼🐨🐯🦁🐮🐷🐸🐵🐔🐧🐦🐤🦆🦅🦉🦇🐺🐗🐴🦄🐝🐛🦋🐌🐞🐜🦂🐢🐍🦎🐙🦑🦀🐡🐠🐟🐬🐳🦈🐊🐅🐆🦓🦍🐘🦏🐪🦒🦘🐃🐑🐐🦌🍎🍐🍊🍋🍌🍉🍇🍓🍒🍑🍍🥥🥝🍅🍆🥑🥦🌽🥕🥔🍞🧀🥚🍔🍕🌮🍩🍪🎂🍭⚽🏀🏈🎾🎲🎸🚗🚀⛵🔑");
+
+        // Alternative renderings of a numeric code for reading aloud, keyed by name.
+        const codeEncoders = {
+            digits: code => code,
+            words: code => {
+                const numBytes = Math.ceil(Math.log2(Math.pow(10, code.length)) / 8);
+                let value = parseInt(code, 10);
+                const bytes = [];
+                for (let i = 0; i < numBytes; i++) {
+                    bytes.unshift(value % 256);
+                    value = Math.floor(value / 256);
+                }
+                return bytes.map((b, i) => (i % 2 === 0 ? PGP_EVEN_WORDS : PGP_ODD_WORDS)[b]).join(' ');
+            },
+            emoji: code => {
+                const padded = code.length % 2 === 0 ? code : '0' + code;
+                return padded.match(/../g).map(pair => CODE_EMOJI[parseInt(pair, 10)]).join(' ');
+            },
+            // Number sign followed by the letter cells a-j, as used for digits in literary Braille
+            braille: code => '⠼' + Array.from(code, d => '⠚⠁⠃⠉⠙⠑⠋⠛⠓⠊'[d]).join(''),
+            large: code => code
+        };
+
+        // Extra styling per format: long renderings shrink, large print grows.
+        const codeFormatClasses = { words: 'code-alt', emoji: 'code-alt', large: 'code-large' };
+
         const elements = {
             title: document.getElementById('titleTxt'),
             subtitle: document.getElementById('subtitleTxt'),
@@ -156,6 +761,9 @@ const i18n = {
             saveModalBtn: document.getElementById('saveModalBtn'),
             closeModalBtn: document.getElementById('closeModalBtn'),
             exportBtn: document.getElementById('exportBtn'),
+            exportEncryptedBtn: document.getElementById('exportEncryptedBtn'),
+            displayArea: document.getElementById('displayArea'),
+            hotpNextBtn: document.getElementById('hotpNextBtn'),
             importBtn: document.getElementById('importBtn'),
             deleteAllBtn: document.getElementById('deleteAllBtn'),
             importInput: document.getElementById('importInput'),
@@ -164,7 +772,29 @@ const i18n = {
             shareBtn: document.getElementById('shareBtn'),
             shareBtnTxt: document.getElementById('shareBtnTxt'),
             shareFeedback: document.getElementById('shareFeedback'),
-            addToDashboardBtn: document.getElementById('addToDashboardBtn')
+            addToDashboardBtn: document.getElementById('addToDashboardBtn'),
+            keyUriBtn: document.getElementById('keyUriBtn'),
+            keyUriBtnTxt: document.getElementById('keyUriBtnTxt'),
+            keyUriFeedback: document.getElementById('keyUriFeedback'),
+            toggleQrBtn: document.getElementById('toggleQrBtn'),
+            qrSection: document.getElementById('qrSection'),
+            qrImage: document.getElementById('qrImage'),
+            scanQrBtn: document.getElementById('scanQrBtn'),
+            qrInput: document.getElementById('qrInput'),
+            generateSecretBtn: document.getElementById('generateSecretBtn'),
+            labelModalOffset: document.getElementById('labelModalOffset'),
+            modalOffset: document.getElementById('modalOffset'),
+            secretBitsSelect: document.getElementById('secretBitsSelect'),
+            formatSelect: document.getElementById('formatSelect'),
+            labelModalExpires: document.getElementById('labelModalExpires'),
+            modalExpires: document.getElementById('modalExpires'),
+            labelModalTags: document.getElementById('labelModalTags'),
+            modalTags: document.getElementById('modalTags'),
+            accountSearch: document.getElementById('accountSearch'),
+            labelModalDigits: document.getElementById('labelModalDigits'),
+            modalDigits: document.getElementById('modalDigits'),
+            labelModalAlphabet: document.getElementById('labelModalAlphabet'),
+            modalAlphabet: document.getElementById('modalAlphabet')
         };
 
         const secretInput = document.getElementById('secret');
@@ -181,6 +811,7 @@ const i18n = {
 
         let currentLang = localStorage.getItem('totp-lang') || 'en';
         let currentTheme = localStorage.getItem('totp-theme') || 'dark';
+        let currentFormat = localStorage.getItem('totp-format') || 'digits';
         let accounts = JSON.parse(localStorage.getItem('totp-accounts') || '[]');
         let activeAccountId = null;
         let editingAccountId = null;
@@ -196,18 +827,31 @@ const i18n = {
             elements.validate.textContent = t.validate;
             elements.verify_now.textContent = t.verify_now;
             elements.label_verify.textContent = t.label_verify;
-            elements.label_steps.textContent = t.label_steps;
+            updateStepsLabel();
             elements.about_title.textContent = t.about_title;
             elements.about_desc.textContent = t.about_desc;
             elements.copy_feedback.textContent = t.copied;
             elements.shareBtnTxt.textContent = t.share;
             elements.shareFeedback.textContent = t.link_copied;
+            elements.keyUriBtnTxt.textContent = t.setup_uri;
+            elements.keyUriFeedback.textContent = t.uri_copied;
+            elements.toggleQrBtn.textContent = t.qr_code;
+            elements.scanQrBtn.textContent = t.scan_qr;
+            elements.generateSecretBtn.textContent = t.generate_secret;
+            elements.labelModalOffset.textContent = t.modal_offset;
+            elements.labelModalExpires.textContent = t.modal_expires;
+            elements.labelModalTags.textContent = t.modal_tags;
+            elements.accountSearch.placeholder = t.search_accounts;
+            elements.labelModalDigits.textContent = t.modal_digits;
+            elements.labelModalAlphabet.textContent = t.modal_alphabet;
             elements.bmc.textContent = t.bmc;
             elements.deleteAllBtn.textContent = t.delete_all;
             
             elements.accountsTitle.textContent = t.accounts_title;
             elements.addNewAccountBtn.textContent = t.add_new;
             elements.exportBtn.textContent = t.export;
+            elements.exportEncryptedBtn.textContent = t.export_encrypted;
+            elements.hotpNextBtn.textContent = t.hotp_next;
             elements.importBtn.textContent = t.import;
             elements.modalTitle.textContent = editingAccountId ? t.modal_title_edit : t.modal_title_add;
             elements.labelModalAccountName.textContent = t.modal_account_name;
@@ -220,6 +864,9 @@ const i18n = {
             }
             
             langSelect.value = lang;
+            Array.from(elements.formatSelect.options).forEach(opt => {
+                opt.textContent = t[`format_${opt.value}`];
+            });
             
             if (currentTheme) {
                 document.getElementById('themeText').textContent = currentTheme === 'dark' ? t.theme_dark : t.theme_light;
@@ -235,9 +882,33 @@ const i18n = {
             document.getElementById('themeText').textContent = currentTheme === 'dark' ? t.theme_dark : t.theme_light;
         }
 
+        let currentCode = null;
+        function renderCode() {
+            if (!currentCode) return;
+            // Alternative renderings only make sense for numeric codes
+            const encode = /^\d+$/.test(currentCode) && codeEncoders[currentFormat] || codeEncoders.digits;
+            totpCode.textContent = encode(currentCode);
+            totpCode.classList.remove('code-alt', 'code-large');
+            if (codeFormatClasses[currentFormat]) totpCode.classList.add(codeFormatClasses[currentFormat]);
+        }
+
+        function clearCode() {
+            currentCode = null;
+            totpCode.textContent = '------';
+            totpCode.classList.remove('code-alt', 'code-large');
+        }
+
+        function applyFormat(format) {
+            currentFormat = format;
+            localStorage.setItem('totp-format', format);
+            elements.formatSelect.value = format;
+            renderCode();
+        }
+
         async function copyToClipboard() {
-            const text = totpCode.textContent;
-            if (text === '------') return;
+            // Always copy the digits, whatever rendering is on screen
+            const text = currentCode;
+            if (!text) return;
             try {
                 await navigator.clipboard.writeText(text);
                 elements.copy_feedback.classList.add('show');
@@ -247,22 +918,132 @@ const i18n = {
             }
         }
 
+        // The stored account being displayed, or the transient one opened from a share link.
+        let sharedAccount = null;
+        function activeAccount() {
+            return accounts.find(a => a.id === activeAccountId) || sharedAccount;
+        }
+
+        // The window field means time steps either side for TOTP, counters ahead for HOTP.
+        function updateStepsLabel() {
+            const t = i18n[currentLang];
+            elements.label_steps.textContent = isHOTP(activeAccount()) ? t.label_hotp_steps : t.label_steps;
+        }
+
+        function totpParams(acc) {
+            return {
+                digits: (acc && acc.digits) || 6,
+                period: (acc && acc.period) || 30,
+                alphabet: (acc && acc.alphabet) || undefined,
+                truncation: (acc && acc.truncation) || undefined,
+                pin: (acc && acc.pin) || undefined,
+                algorithm: (acc && acc.algorithm) || 'SHA1'
+            };
+        }
+
+        // Per-account clock correction for providers whose clocks run off true time.
+        function currentOffsetMs() {
+            const acc = activeAccount();
+            return acc && acc.offset ? acc.offset * 1000 : 0;
+        }
+
+        // Accounts may carry an `expires` date (YYYY-MM-DD), valid through the end of that local day.
+        const EXPIRY_WARNING_DAYS = 14;
+        function daysUntilExpiry(acc) {
+            if (!acc || !acc.expires) return null;
+            const end = new Date(`${acc.expires}T23:59:59.999`).getTime();
+            return Math.floor((end - Date.now()) / 86400000);
+        }
+
+        function isExpired(acc) {
+            const days = daysUntilExpiry(acc);
+            return days !== null && days < 0;
+        }
+
+        // HOTP accounts store `counter`: the next counter value that hasn't been used yet.
+        function isHOTP(acc) {
+            return !!acc && acc.type === 'hotp';
+        }
+
+        // Mirrors the strategy pick in generateHOTP, for deciding which links can reproduce the codes.
+        function truncationOf(acc) {
+            if (!acc) return 'standard';
+            return acc.truncation || (acc.alphabet ? 'alphabet' : 'standard');
+        }
+
+        // RFC 4226 section 7.4 look-ahead window used when validating HOTP codes.
+        function hotpLookAhead() {
+            return Math.max(parseInt(windowStepsInput.value.trim() || '1', 10) || 0, 0);
+        }
+
+        function setHOTPCounter(acc, counter) {
+            updateAccountsState(accounts.map(a => a.id === acc.id ? { ...a, counter } : a));
+            displayedStep = null;
+            updateProgress();
+        }
+
         let refreshTimer = null;
+        let displayedStep = null;
         function updateProgress() {
-            const now = new Date();
-            const seconds = now.getSeconds() % 30;
-            const remaining = 30 - seconds;
-            const progress = (remaining / 30) * 100;
+            const acc = activeAccount();
+            elements.displayArea.classList.toggle('hotp-mode', isHOTP(acc));
+            if (isHOTP(acc)) {
+                // Counter-based codes don't expire, so only refetch when the counter moves
+                const step = `hotp:${acc.counter || 0}`;
+                if (step !== displayedStep) {
+                    displayedStep = step;
+                    fetchTotp();
+                }
+                return;
+            }
+            const now = Date.now() + currentOffsetMs();
+            const { period } = totpParams(activeAccount());
+            const remaining = period - Math.floor(now / 1000) % period;
+            const progress = (remaining / period) * 100;
             progressBar.style.width = progress + '%';
             timerText.textContent = remaining;
-            if (seconds === 0) fetchTotp();
+            // Compare step counters rather than waiting for second 0, so a late tick can't skip a rollover
+            const step = Math.floor(now / (period * 1000));
+            if (step !== displayedStep) {
+                displayedStep = step;
+                fetchTotp();
+            }
+        }
+
+        // Ticks on whole-second boundaries, re-arming from the current time each
+        // run instead of a fixed interval so timer drift never accumulates.
+        function startRefresh() {
+            stopRefresh();
+            const tick = () => {
+                updateProgress();
+                refreshTimer = setTimeout(tick, 1000 - (Date.now() + currentOffsetMs()) % 1000);
+            };
+            tick();
+        }
+
+        function stopRefresh() {
+            clearTimeout(refreshTimer);
+            refreshTimer = null;
+            displayedStep = null;
         }
 
         async function fetchTotp() {
             const secret = secretInput.value.trim();
             if (!secret) return;
-            const totp = await generateTOTP(secret);
-            if (totp) totpCode.textContent = totp;
+            if (isExpired(activeAccount())) {
+                clearCode();
+                totpCode.textContent = i18n[currentLang].expired;
+                totpCode.classList.add('code-alt');
+                return;
+            }
+            const acc = activeAccount();
+            const totp = isHOTP(acc)
+                ? await generateHOTP(secret, acc.counter || 0, totpParams(acc))
+                : await generateTOTP(secret, Date.now() + currentOffsetMs(), totpParams(acc));
+            if (totp) {
+                currentCode = totp;
+                renderCode();
+            }
         }
 
         async function verifyCode() {
@@ -272,15 +1053,34 @@ const i18n = {
             if (!secret || !code) return;
 
             statusBadge.classList.remove('hidden', 'status-valid', 'status-invalid');
+            if (isExpired(activeAccount())) {
+                statusBadge.textContent = i18n[currentLang].expired;
+                statusBadge.style.color = 'var(--error)';
+                return;
+            }
             let isValid = false;
 
-            const now = Date.now();
-            for (let i = -windowSteps; i <= windowSteps; i++) {
-                const checkTime = now + (i * 30000);
-                const checkOtp = await generateTOTP(secret, checkTime);
-                if (checkOtp === code) {
-                    isValid = true;
-                    break;
+            const acc = activeAccount();
+            const now = Date.now() + currentOffsetMs();
+            const params = totpParams(acc);
+            if (isHOTP(acc)) {
+                // Look ahead from the stored counter and resynchronise past any codes the token skipped
+                const start = acc.counter || 0;
+                for (let c = start; c <= start + hotpLookAhead(); c++) {
+                    if (await generateHOTP(secret, c, params) === code) {
+                        isValid = true;
+                        if (accounts.includes(acc)) setHOTPCounter(acc, c + 1);
+                        break;
+                    }
+                }
+            } else {
+                for (let i = -windowSteps; i <= windowSteps; i++) {
+                    const checkTime = now + (i * params.period * 1000);
+                    const checkOtp = await generateTOTP(secret, checkTime, params);
+                    if (checkOtp === code) {
+                        isValid = true;
+                        break;
+                    }
                 }
             }
 
@@ -303,15 +1103,36 @@ const i18n = {
             renderAccounts();
         }
 
+        function escapeHTML(text) {
+            return String(text).replace(/[&<>"']/g, c => ({ '&': '&amp;', '<': '&lt;', '>': '&gt;', '"': '&quot;', "'": '&#39;' })[c]);
+        }
+
+        // Case-insensitive substring match against the account name and its tags.
+        function matchesAccountSearch(acc, query) {
+            if (!query) return true;
+            return [acc.name || '', ...(acc.tags || [])].some(text => text.toLowerCase().includes(query));
+        }
+
         function renderAccounts() {
             elements.accountsList.innerHTML = '';
-            accounts.forEach(acc => {
+            const t = i18n[currentLang];
+            const query = elements.accountSearch.value.trim().toLowerCase();
+            accounts.filter(acc => matchesAccountSearch(acc, query)).forEach(acc => {
                 const card = document.createElement('div');
-                card.className = `account-card ${acc.id === activeAccountId ? 'active' : ''}`;
+                const days = daysUntilExpiry(acc);
+                let expiryNote = '';
+                if (days !== null && days < 0) {
+                    expiryNote = `<span class="account-expiry">${t.expired}</span>`;
+                } else if (days !== null && days <= EXPIRY_WARNING_DAYS) {
+                    expiryNote = `<span class="account-expiry">${t.expires_in.replace('{n}', days)}</span>`;
+                }
+                card.className = `account-card ${acc.id === activeAccountId ? 'active' : ''} ${days !== null && days < 0 ? 'expired' : ''}`;
                 card.innerHTML = `
                     <div class="account-info">
-                        <span class="account-name">${acc.name}</span>
+                        <span class="account-name">${escapeHTML(acc.name)}</span>
                         <span class="account-secret-preview">${acc.secret.substr(0, 4)}...${acc.secret.substr(-4)}</span>
+                        ${expiryNote}
+                        ${(acc.tags || []).length ? `<span class="account-tags">${acc.tags.map(tag => `<span class="account-tag">${escapeHTML(tag)}</span>`).join('')}</span>` : ''}
                     </div>
                     <div class="account-actions">
                         <button class="action-btn edit-btn" data-id="${acc.id}" title="Edit Account">
@@ -346,25 +1167,29 @@ const i18n = {
         function showAccountTotp(acc) {
             activeAccountId = acc.id;
             secretInput.value = acc.secret;
-            elements.shareBtn.classList.remove('hidden');
+            // Share links are time-based only; a shared HOTP counter would drift immediately.
+            // They also carry no truncation, and setup URIs only know Steam's encoder=.
+            elements.shareBtn.classList.toggle('hidden', isHOTP(acc) || truncationOf(acc) !== 'standard');
+            const portable = ['standard', 'steam'].includes(truncationOf(acc));
+            elements.keyUriBtn.classList.toggle('hidden', !portable);
+            elements.toggleQrBtn.classList.toggle('hidden', !portable);
+            if (!portable) elements.qrSection.classList.add('hidden');
+            updateStepsLabel();
             renderAccounts(); // Re-render to update active class
-            fetchTotp();
-            if (!refreshTimer) refreshTimer = setInterval(updateProgress, 1000);
-            updateProgress();
+            renderKeyQR();
+            startRefresh();
         }
 
         function deleteAccount(id) {
             if (activeAccountId === id) {
                 activeAccountId = null;
                 secretInput.value = '';
-                totpCode.textContent = '------';
+                clearCode();
                 progressBar.style.width = '100%';
                 timerText.textContent = '30';
                 elements.shareBtn.classList.add('hidden');
-                if (refreshTimer) {
-                    clearInterval(refreshTimer);
-                    refreshTimer = null;
-                }
+                renderKeyQR();
+                stopRefresh();
             }
             updateAccountsState(accounts.filter(a => a.id !== id));
         }
@@ -373,24 +1198,22 @@ const i18n = {
             if (confirm(i18n[currentLang].confirm_delete_all)) {
                 activeAccountId = null;
                 secretInput.value = '';
-                totpCode.textContent = '------';
+                clearCode();
                 progressBar.style.width = '100%';
                 timerText.textContent = '30';
                 elements.shareBtn.classList.add('hidden');
-                if (refreshTimer) {
-                    clearInterval(refreshTimer);
-                    refreshTimer = null;
-                }
+                renderKeyQR();
+                stopRefresh();
                 updateAccountsState([]);
             }
         }
 
-        function saveAccount(name, secret) {
+        function saveAccount(fields) {
             if (editingAccountId) {
                 // Edit existing account
                 const updatedAccounts = accounts.map(a => {
                     if (a.id === editingAccountId) {
-                        return { ...a, name, secret };
+                        return { ...a, ...fields };
                     }
                     return a;
                 });
@@ -398,18 +1221,15 @@ const i18n = {
                 
                 // If it's the active account being edited, update UI
                 if (activeAccountId === editingAccountId) {
-                    secretInput.value = secret;
-                    totpCode.textContent = "------";
-                    if (refreshTimer) {
-                        clearInterval(refreshTimer);
-                        refreshTimer = null;
-                    }
+                    secretInput.value = fields.secret;
+                    clearCode();
+                    stopRefresh();
                     showAccountTotp(updatedAccounts.find(a => a.id === editingAccountId));
                 }
             } else {
                 // Add new account
                 const id = Date.now().toString();
-                updateAccountsState([...accounts, { id, name, secret }]);
+                updateAccountsState([...accounts, { id, ...fields }]);
             }
             elements.accountModal.classList.remove('show');
             editingAccountId = null;
@@ -420,35 +1240,470 @@ const i18n = {
             elements.modalTitle.textContent = i18n[currentLang].modal_title_edit;
             elements.modalAccountName.value = acc.name;
             elements.modalSecret.value = acc.secret;
+            elements.modalOffset.value = acc.offset || 0;
+            elements.modalExpires.value = acc.expires || '';
+            elements.modalTags.value = (acc.tags || []).join(', ');
+            elements.modalDigits.value = acc.digits || 6;
+            elements.modalAlphabet.value = acc.alphabet || '';
             elements.accountModal.classList.add('show');
         }
 
-        function exportAccounts() {
-            const data = JSON.stringify(accounts, null, 2);
+        function downloadJSON(value, suffix = '') {
+            const data = JSON.stringify(value, null, 2);
             const blob = new Blob([data], { type: 'application/json' });
             const url = URL.createObjectURL(blob);
             const a = document.createElement('a');
             a.href = url;
-            a.download = `totp-backup-${new Date().toISOString().split('T')[0]}.json`;
+            a.download = `totp-backup-${new Date().toISOString().split('T')[0]}${suffix}.json`;
             a.click();
         }
 
+        function exportAccounts() {
+            downloadJSON(accounts);
+        }
+
+        // --- Encrypted Backups ---
+        // Our own export, sealed with AES-GCM under a PBKDF2-SHA256 key derived from a passphrase.
+
+        const ENCRYPTED_BACKUP_FORMAT = 'totp-viewer-encrypted';
+        const BACKUP_KDF_ITERATIONS = 600000;
+        // Iteration counts read back from a file must fall in this range, so a crafted backup
+        // can neither hang the tab nor quietly weaken the key derivation
+        const BACKUP_KDF_MIN_ITERATIONS = 100000;
+        const BACKUP_KDF_MAX_ITERATIONS = 10000000;
+
+        const bufToBase64 = buf => btoa(Array.from(buf, b => String.fromCharCode(b)).join(''));
+        const base64ToBuf = str => Uint8Array.from(atob(str), c => c.charCodeAt(0));
+
+        async function backupKey(password, salt, iterations) {
+            const baseKey = await crypto.subtle.importKey("raw", new TextEncoder().encode(password), "PBKDF2", false, ["deriveKey"]);
+            return crypto.subtle.deriveKey(
+                { name: "PBKDF2", salt, iterations, hash: "SHA-256" },
+                baseKey, { name: "AES-GCM", length: 256 }, false, ["encrypt", "decrypt"]
+            );
+        }
+
+        async function exportEncryptedAccounts() {
+            const t = i18n[currentLang];
+            const password = prompt(t.backup_new_password);
+            if (!password) return;
+            if (prompt(t.backup_confirm_password) !== password) {
+                alert(t.backup_password_mismatch);
+                return;
+            }
+            const salt = crypto.getRandomValues(new Uint8Array(16));
+            const iv = crypto.getRandomValues(new Uint8Array(12));
+            const key = await backupKey(password, salt, BACKUP_KDF_ITERATIONS);
+            const sealed = await crypto.subtle.encrypt({ name: "AES-GCM", iv }, key, new TextEncoder().encode(JSON.stringify(accounts)));
+            downloadJSON({
+                format: ENCRYPTED_BACKUP_FORMAT,
+                version: 1,
+                kdf: { name: "PBKDF2", hash: "SHA-256", iterations: BACKUP_KDF_ITERATIONS, salt: bufToBase64(salt) },
+                cipher: { name: "AES-GCM", iv: bufToBase64(iv) },
+                data: bufToBase64(new Uint8Array(sealed))
+            }, '-encrypted');
+        }
+
+        // Resolves to the account list inside an encrypted backup, or null if the user
+        // cancels or the password is wrong.
+        async function decryptBackup(backup) {
+            const t = i18n[currentLang];
+            const iterations = backup.kdf && backup.kdf.iterations;
+            if (!Number.isInteger(iterations) || iterations < BACKUP_KDF_MIN_ITERATIONS || iterations > BACKUP_KDF_MAX_ITERATIONS) {
+                alert(t.backup_unsupported);
+                return null;
+            }
+            const password = prompt(t.backup_password);
+            if (!password) return null;
+            try {
+                const key = await backupKey(password, base64ToBuf(backup.kdf.salt), iterations);
+                const plain = await crypto.subtle.decrypt({ name: "AES-GCM", iv: base64ToBuf(backup.cipher.iv) }, key, base64ToBuf(backup.data));
+                return JSON.parse(new TextDecoder().decode(plain));
+            } catch {
+                alert(t.backup_wrong_password);
+                return null;
+            }
+        }
+
+        // Imported accounts are named "Issuer (label)", or whichever part the entry has.
+        function importedName(issuer, label, fallback) {
+            if (issuer) return label ? `${issuer} (${label})` : issuer;
+            return label || fallback;
+        }
+
+        // Decrypted Authy backups: either the raw token list or an object holding
+        // `authenticator_tokens` and `apps`. Authy's own app tokens are 7 digits every 10s.
+        function importAuthyBackup(data) {
+            let tokens, apps;
+            if (Array.isArray(data)) {
+                tokens = data;
+                apps = [];
+            } else if (data && (data.authenticator_tokens || data.apps)) {
+                tokens = data.authenticator_tokens || [];
+                apps = data.apps || [];
+            } else {
+                return null;
+            }
+            const isAuthy = t => t && ['decryptedSeed', 'decrypted_seed', 'secret_seed', 'account_type'].some(k => k in t);
+            if (![...tokens, ...apps].some(isAuthy)) return null;
+
+            // Authy app seeds (secret_seed) are hex; authenticator token seeds are base32
+            const seedToBase32 = t => {
+                if (t.secret_seed) {
+                    const hex = String(t.secret_seed).replace(/^0x/i, '');
+                    return /^([0-9a-f]{2})+$/i.test(hex) ? bufToBase32(hexToBuf(hex)) : null;
+                }
+                const parsed = parseSecretInput(t.decryptedSeed || t.decrypted_seed || t.secret || '');
+                return parsed ? parsed.secret : null;
+            };
+            const toAccount = (t, digits, period) => {
+                const secret = seedToBase32(t);
+                const tokenDigits = t.digits || digits;
+                if (!secret || !isValidDigits(tokenDigits)) return null;
+                return { name: t.name || t.original_name || 'Authy', secret, digits: tokenDigits, period };
+            };
+            return [
+                ...tokens.map(t => toAccount(t, 6, 30)),
+                ...apps.map(t => toAccount(t, 7, 10))
+            ].filter(Boolean);
+        }
+
+        // --- scrypt (RFC 7914), needed to unlock encrypted Aegis vaults ---
+
+        function salsa20_8(b) {
+            const x = b.slice();
+            const rotl = (v, n) => (v << n) | (v >>> (32 - n));
+            for (let i = 0; i < 8; i += 2) {
+                x[4] ^= rotl(x[0] + x[12], 7); x[8] ^= rotl(x[4] + x[0], 9);
+                x[12] ^= rotl(x[8] + x[4], 13); x[0] ^= rotl(x[12] + x[8], 18);
+                x[9] ^= rotl(x[5] + x[1], 7); x[13] ^= rotl(x[9] + x[5], 9);
+                x[1] ^= rotl(x[13] + x[9], 13); x[5] ^= rotl(x[1] + x[13], 18);
+                x[14] ^= rotl(x[10] + x[6], 7); x[2] ^= rotl(x[14] + x[10], 9);
+                x[6] ^= rotl(x[2] + x[14], 13); x[10] ^= rotl(x[6] + x[2], 18);
+                x[3] ^= rotl(x[15] + x[11], 7); x[7] ^= rotl(x[3] + x[15], 9);
+                x[11] ^= rotl(x[7] + x[3], 13); x[15] ^= rotl(x[11] + x[7], 18);
+                x[1] ^= rotl(x[0] + x[3], 7); x[2] ^= rotl(x[1] + x[0], 9);
+                x[3] ^= rotl(x[2] + x[1], 13); x[0] ^= rotl(x[3] + x[2], 18);
+                x[6] ^= rotl(x[5] + x[4], 7); x[7] ^= rotl(x[6] + x[5], 9);
+                x[4] ^= rotl(x[7] + x[6], 13); x[5] ^= rotl(x[4] + x[7], 18);
+                x[11] ^= rotl(x[10] + x[9], 7); x[8] ^= rotl(x[11] + x[10], 9);
+                x[9] ^= rotl(x[8] + x[11], 13); x[10] ^= rotl(x[9] + x[8], 18);
+                x[12] ^= rotl(x[15] + x[14], 7); x[13] ^= rotl(x[12] + x[15], 9);
+                x[14] ^= rotl(x[13] + x[12], 13); x[15] ^= rotl(x[14] + x[13], 18);
+            }
+            for (let i = 0; i < 16; i++) b[i] += x[i];
+        }
+
+        function scryptBlockMix(b, y, r) {
+            const x = b.slice((2 * r - 1) * 16, 2 * r * 16);
+            for (let i = 0; i < 2 * r; i++) {
+                for (let k = 0; k < 16; k++) x[k] ^= b[i * 16 + k];
+                salsa20_8(x);
+                // Even blocks go to the first half of the output, odd blocks to the second
+                y.set(x, ((i >> 1) + (i & 1) * r) * 16);
+            }
+            b.set(y);
+        }
+
+        async function scrypt(password, salt, n, r, p, dkLen) {
+            const pbkdf2 = async (pw, s, len) => {
+                const key = await crypto.subtle.importKey("raw", pw, "PBKDF2", false, ["deriveBits"]);
+                const bits = await crypto.subtle.deriveBits({ name: "PBKDF2", salt: s, iterations: 1, hash: "SHA-256" }, key, len * 8);
+                return new Uint8Array(bits);
+            };
+            const blockWords = 32 * r;
+            const b = await pbkdf2(password, salt, p * 128 * r);
+            const view = new DataView(b.buffer);
+            const v = new Uint32Array(blockWords * n);
+            const x = new Uint32Array(blockWords);
+            const y = new Uint32Array(blockWords);
+            for (let block = 0; block < p; block++) {
+                const base = block * 128 * r;
+                for (let i = 0; i < blockWords; i++) x[i] = view.getUint32(base + i * 4, true);
+                for (let i = 0; i < n; i++) {
+                    v.set(x, i * blockWords);
+                    scryptBlockMix(x, y, r);
+                }
+                for (let i = 0; i < n; i++) {
+                    const j = x[(2 * r - 1) * 16] & (n - 1);
+                    for (let k = 0; k < blockWords; k++) x[k] ^= v[j * blockWords + k];
+                    scryptBlockMix(x, y, r);
+                }
+                for (let i = 0; i < blockWords; i++) view.setUint32(base + i * 4, x[i], true);
+            }
+            return pbkdf2(password, b, dkLen);
+        }
+
+        // --- Aegis ---
+
+        async function aesGcmDecrypt(keyBytes, nonceHex, tagHex, ciphertext) {
+            const key = await crypto.subtle.importKey("raw", keyBytes, "AES-GCM", false, ["decrypt"]);
+            const sealed = new Uint8Array(ciphertext.length + tagHex.length / 2);
+            sealed.set(ciphertext);
+            sealed.set(hexToBuf(tagHex), ciphertext.length);
+            return new Uint8Array(await crypto.subtle.decrypt({ name: "AES-GCM", iv: hexToBuf(nonceHex) }, key, sealed));
+        }
+
+        // Tries each password slot until one unwraps the master key, then decrypts the vault.
+        // Aegis writes n = 2^15, r = 8, p = 1. scrypt allocates 128 * r * n bytes, so parameters
+        // read from a vault are capped to keep a crafted file from exhausting the tab's memory
+        const AEGIS_SCRYPT_MAX_N = 2 ** 18;
+        const AEGIS_SCRYPT_MAX_R = 8;
+        const AEGIS_SCRYPT_MAX_P = 4;
+        const isPositiveInteger = v => Number.isInteger(v) && v > 0;
+
+        // Password slots (type 1) whose scrypt parameters we are willing to run.
+        function aegisPasswordSlots(data) {
+            return (data.header.slots || []).filter(slot => slot && slot.type === 1
+                && isPositiveInteger(slot.n) && slot.n > 1 && (slot.n & (slot.n - 1)) === 0 && slot.n <= AEGIS_SCRYPT_MAX_N
+                && isPositiveInteger(slot.r) && slot.r <= AEGIS_SCRYPT_MAX_R
+                && isPositiveInteger(slot.p) && slot.p <= AEGIS_SCRYPT_MAX_P);
+        }
+
+        async function decryptAegisVault(data, password) {
+            const passwordBytes = new TextEncoder().encode(password);
+            for (const slot of aegisPasswordSlots(data)) {
+                try {
+                    const derived = await scrypt(passwordBytes, hexToBuf(slot.salt), slot.n, slot.r, slot.p, 32);
+                    const masterKey = await aesGcmDecrypt(derived, slot.key_params.nonce, slot.key_params.tag, hexToBuf(slot.key));
+                    const db = base64ToBuf(data.db);
+                    const plain = await aesGcmDecrypt(masterKey, data.header.params.nonce, data.header.params.tag, db);
+                    return JSON.parse(new TextDecoder().decode(plain));
+                } catch {
+                    // Wrong password for this slot, try the next one
+                }
+            }
+            return null;
+        }
+
+        // Aegis vault exports, plain or password-encrypted. mOTP entries are skipped.
+        async function importAegisBackup(data) {
+            if (!data || !data.header || data.db === undefined) return null;
+            let db = data.db;
+            if (typeof db === 'string') {
+                if (aegisPasswordSlots(data).length === 0) {
+                    alert(i18n[currentLang].backup_unsupported);
+                    return [];
+                }
+                const password = prompt(i18n[currentLang].backup_password);
+                if (!password) return [];
+                db = await decryptAegisVault(data, password);
+                if (!db) {
+                    alert(i18n[currentLang].backup_wrong_password);
+                    return [];
+                }
+            }
+            return (db.entries || []).map(entry => {
+                const info = entry.info || {};
+                if (!['totp', 'hotp', 'steam', 'yandex'].includes(entry.type) || !HMAC_ALGORITHMS[info.algo || 'SHA1']) return null;
+                const parsed = parseSecretInput(info.secret || '');
+                if (!parsed) return null;
+                const truncation = ['steam', 'yandex'].includes(entry.type) ? entry.type : undefined;
+                const digits = info.digits || 6;
+                const period = info.period || 30;
+                if (!isValidCodeLength(digits, truncation) || !isValidPeriod(period)) return null;
+                const label = entry.name || '';
+                return {
+                    name: importedName(entry.issuer, label, 'Aegis'),
+                    secret: parsed.secret,
+                    digits,
+                    period,
+                    algorithm: info.algo || 'SHA1',
+                    truncation,
+                    pin: entry.type === 'yandex' ? info.pin : undefined,
+                    type: entry.type === 'hotp' ? 'hotp' : undefined,
+                    counter: entry.type === 'hotp' ? info.counter || 0 : undefined
+                };
+            }).filter(Boolean);
+        }
+
+        // --- 2FAS ---
+
+        // Encrypted exports store "ciphertext:salt:iv" (base64) sealed with a PBKDF2-derived AES-GCM key.
+        async function decrypt2FASServices(encrypted, password) {
+            const [ciphertext, salt, iv] = encrypted.split(':').map(base64ToBuf);
+            try {
+                const key = await backupKey(password, salt, 10000);
+                const plain = await crypto.subtle.decrypt({ name: "AES-GCM", iv }, key, ciphertext);
+                return JSON.parse(new TextDecoder().decode(plain));
+            } catch {
+                return null;
+            }
+        }
+
+        // 2FAS Auth .2fas exports.
+        async function import2FASBackup(data) {
+            if (!data || !data.schemaVersion || !(data.services || data.servicesEncrypted)) return null;
+            let services = data.services || [];
+            if (data.servicesEncrypted) {
+                const password = prompt(i18n[currentLang].backup_password);
+                if (!password) return [];
+                services = await decrypt2FASServices(data.servicesEncrypted, password);
+                if (!services) {
+                    alert(i18n[currentLang].backup_wrong_password);
+                    return [];
+                }
+            }
+            return services.map(service => {
+                const otp = service.otp || {};
+                const tokenType = otp.tokenType || 'TOTP';
+                const algorithm = otp.algorithm || 'SHA1';
+                if (!['TOTP', 'HOTP', 'STEAM'].includes(tokenType) || !HMAC_ALGORITHMS[algorithm]) return null;
+                const parsed = parseSecretInput(service.secret || '');
+                if (!parsed) return null;
+                const truncation = tokenType === 'STEAM' ? 'steam' : undefined;
+                const digits = otp.digits || 6;
+                const period = otp.period || 30;
+                if (!isValidCodeLength(digits, truncation) || !isValidPeriod(period)) return null;
+                const label = otp.account || otp.label || '';
+                return {
+                    name: importedName(service.name, label, '2FAS'),
+                    secret: parsed.secret,
+                    digits,
+                    period,
+                    algorithm,
+                    truncation,
+                    type: tokenType === 'HOTP' ? 'hotp' : undefined,
+                    counter: tokenType === 'HOTP' ? otp.counter || 0 : undefined
+                };
+            }).filter(Boolean);
+        }
+
+        // --- FreeOTP+ ---
+
+        // FreeOTP+ JSON exports keep each secret as an array of signed bytes.
+        function importFreeOTPPlusBackup(data) {
+            if (!data || !Array.isArray(data.tokens) || !Array.isArray(data.tokenOrder)) return null;
+            return data.tokens.map(token => {
+                const algorithm = token.algo || 'SHA1';
+                if (!['TOTP', 'HOTP'].includes(token.type) || !Array.isArray(token.secret) || !HMAC_ALGORITHMS[algorithm]) return null;
+                const digits = token.digits || 6;
+                const period = token.period || 30;
+                if (!isValidDigits(digits) || !isValidPeriod(period)) return null;
+                const issuer = token.issuerExt || token.issuerInt || '';
+                const label = token.label || '';
+                return {
+                    name: importedName(issuer, label, 'FreeOTP+'),
+                    secret: bufToBase32(Uint8Array.from(token.secret, b => b & 0xff)),
+                    digits,
+                    period,
+                    algorithm,
+                    type: token.type === 'HOTP' ? 'hotp' : undefined,
+                    counter: token.type === 'HOTP' ? token.counter || 0 : undefined
+                };
+            }).filter(Boolean);
+        }
+
+        // --- Google Authenticator ---
+
+        // Minimal protobuf reader: returns [fieldNumber, value] pairs, where value is a number
+        // for varints and a Uint8Array for length-delimited fields. Other wire types are rejected.
+        function readProtobuf(bytes) {
+            const fields = [];
+            let pos = 0;
+            const varint = () => {
+                let value = 0;
+                let scale = 1;
+                let byte;
+                do {
+                    if (pos >= bytes.length) throw new Error('truncated varint');
+                    byte = bytes[pos++];
+                    value += (byte & 0x7f) * scale;
+                    scale *= 128;
+                } while (byte & 0x80);
+                return value;
+            };
+            while (pos < bytes.length) {
+                const key = varint();
+                const wireType = key % 8;
+                if (wireType === 0) {
+                    fields.push([Math.floor(key / 8), varint()]);
+                } else if (wireType === 2) {
+                    const length = varint();
+                    if (pos + length > bytes.length) throw new Error('truncated field');
+                    fields.push([Math.floor(key / 8), bytes.subarray(pos, pos + length)]);
+                    pos += length;
+                } else {
+                    throw new Error('unsupported wire type ' + wireType);
+                }
+            }
+            return fields;
+        }
+
+        // Decodes an otpauth-migration:// URI from Google Authenticator's "Transfer accounts" QR.
+        // Returns the accounts in it (MD5 entries are skipped), or null if it isn't one.
+        function parseGoogleMigrationURI(text) {
+            if (!/^otpauth-migration:\/\//i.test((text || '').trim())) return null;
+            try {
+                const data = new URL(text.trim()).searchParams.get('data') || '';
+                const payload = base64ToBuf(data);
+                const algorithms = { 0: 'SHA1', 1: 'SHA1', 2: 'SHA256', 3: 'SHA512' };
+                return readProtobuf(payload).filter(([field]) => field === 1).map(([, otp]) => {
+                    const entry = {};
+                    for (const [field, value] of readProtobuf(otp)) entry[field] = value;
+                    if (!(entry[1] instanceof Uint8Array) || !algorithms[entry[4] || 0]) return null;
+                    const decode = v => v instanceof Uint8Array ? new TextDecoder().decode(v) : '';
+                    const issuer = decode(entry[3]);
+                    let label = decode(entry[2]);
+                    // Labels are usually "issuer:account"; only strip a prefix that really is the issuer
+                    if (issuer && label.startsWith(issuer + ':')) label = label.slice(issuer.length + 1);
+                    label = label.trim();
+                    return {
+                        name: importedName(issuer, label, 'Google Authenticator'),
+                        secret: bufToBase32(entry[1]),
+                        digits: entry[5] === 2 ? 8 : 6,
+                        algorithm: algorithms[entry[4] || 0],
+                        // Field 6 is the token type (1 = HOTP, 2 = TOTP), field 7 the HOTP counter
+                        type: entry[6] === 1 ? 'hotp' : undefined,
+                        counter: entry[6] === 1 ? entry[7] || 0 : undefined
+                    };
+                }).filter(Boolean);
+            } catch (err) {
+                console.error("Migration QR Error:", err);
+                return [];
+            }
+        }
+
+        // Third-party backup formats, tried in order before falling back to our own export format.
+        // Each returns (or resolves to) the accounts it recognised, or null if the data isn't in its format.
+        const backupImporters = [importAuthyBackup, importAegisBackup, import2FASBackup, importFreeOTPPlusBackup];
+
+        // Migrations add to the existing accounts rather than replacing them
+        function appendImportedAccounts(imported) {
+            const now = Date.now();
+            updateAccountsState([...accounts, ...imported.map((acc, i) => ({ id: (now + i).toString(), ...acc }))]);
+            activeAccountId = null;
+            if (accounts.length > 0) showAccountTotp(accounts[0]);
+        }
+
         function importAccounts(e) {
             const file = e.target.files[0];
             if (!file) return;
             const reader = new FileReader();
-            reader.onload = (event) => {
+            reader.onload = async (event) => {
                 try {
-                    const imported = JSON.parse(event.target.result);
-                    if (Array.isArray(imported)) {
+                    let imported = JSON.parse(event.target.result);
+                    if (imported && imported.format === ENCRYPTED_BACKUP_FORMAT) {
+                        imported = await decryptBackup(imported);
+                        if (!imported) return;
+                    }
+                    let foreign = null;
+                    for (const importer of backupImporters) {
+                        foreign = await importer(imported);
+                        if (foreign) break;
+                    }
+                    if (foreign) {
+                        appendImportedAccounts(foreign);
+                    } else if (Array.isArray(imported)) {
                         updateAccountsState(imported);
                         activeAccountId = null;
                         if (accounts.length > 0) {
                             showAccountTotp(accounts[0]);
                         } else {
                             secretInput.value = '';
-                            totpCode.textContent = '------';
+                            clearCode();
                             elements.shareBtn.classList.add('hidden');
+                            renderKeyQR();
                         }
                     }
                 } catch (err) {
@@ -464,6 +1719,11 @@ const i18n = {
             elements.modalTitle.textContent = i18n[currentLang].modal_title_add;
             elements.modalAccountName.value = '';
             elements.modalSecret.value = '';
+            elements.modalOffset.value = 0;
+            elements.modalExpires.value = '';
+            elements.modalTags.value = '';
+            elements.modalDigits.value = 6;
+            elements.modalAlphabet.value = '';
             elements.accountModal.classList.add('show');
         };
 
@@ -472,18 +1732,123 @@ const i18n = {
             elements.accountModal.classList.remove('show');
         };
 
+        function readModalAccount() {
+            if (!elements.modalSecret.value.trim()) return null;
+            const parsed = parseSecretInput(elements.modalSecret.value);
+            if (!parsed) {
+                alert(i18n[currentLang].invalid_secret);
+                return null;
+            }
+            const name = elements.modalAccountName.value.trim() || parsed.name;
+            const offset = Number(elements.modalOffset.value || 0);
+            if (!isValidOffset(offset)) {
+                alert(i18n[currentLang].invalid_offset);
+                return null;
+            }
+            const expires = elements.modalExpires.value || undefined;
+            const tags = [...new Set(elements.modalTags.value.split(',').map(tag => tag.trim()).filter(Boolean))];
+            if (!name) return null;
+            // An explicit digits= in a pasted otpauth:// URI wins over the length field
+            const digits = parsed.digits || parseInt(elements.modalDigits.value, 10) || 6;
+            const alphabet = elements.modalAlphabet.value.trim() || undefined;
+            // Edited accounts keep their stored strategy unless a pasted URI names another one
+            const editing = accounts.find(a => a.id === editingAccountId);
+            const truncation = parsed.truncation || (editing && editing.truncation);
+            const lengths = truncation && Object.hasOwn(truncationCodeLengths, truncation) && truncationCodeLengths[truncation];
+            if (lengths) {
+                if (digits < lengths.min || digits > lengths.max) {
+                    alert(i18n[currentLang].invalid_code_length.replace('{min}', lengths.min).replace('{max}', lengths.max));
+                    return null;
+                }
+            } else if (alphabet) {
+                const chars = Array.from(alphabet);
+                if (chars.length < 2 || new Set(chars).size !== chars.length) {
+                    alert(i18n[currentLang].invalid_alphabet);
+                    return null;
+                }
+                const maxLength = maxAlphabetCodeLength(chars.length);
+                if (digits < 1 || digits > maxLength) {
+                    alert(i18n[currentLang].invalid_alphabet_length.replace('{n}', maxLength));
+                    return null;
+                }
+            } else if (!isValidDigits(digits)) {
+                alert(i18n[currentLang].invalid_digits);
+                return null;
+            }
+            const fields = { name, secret: parsed.secret, offset, expires, digits, alphabet, tags: tags.length ? tags : undefined };
+            if (parsed.period) fields.period = parsed.period;
+            if (parsed.truncation) fields.truncation = parsed.truncation;
+            if (parsed.pin) fields.pin = parsed.pin;
+            if (parsed.algorithm) fields.algorithm = parsed.algorithm;
+            if (parsed.type === 'hotp') {
+                fields.type = 'hotp';
+                fields.counter = parsed.counter;
+            }
+            return fields;
+        }
+
+        // A Google Authenticator transfer QR carries a batch of accounts, so it bypasses the modal
+        function importMigrationFromModal(text) {
+            const migrated = parseGoogleMigrationURI(text);
+            if (!migrated) return false;
+            if (migrated.length === 0) {
+                alert(i18n[currentLang].qr_not_found);
+                return true;
+            }
+            elements.accountModal.classList.remove('show');
+            editingAccountId = null;
+            appendImportedAccounts(migrated);
+            return true;
+        }
+
         elements.saveModalBtn.onclick = () => {
-            const name = elements.modalAccountName.value.trim();
-            const secret = elements.modalSecret.value.trim();
-            if (name && secret) saveAccount(name, secret);
+            if (importMigrationFromModal(elements.modalSecret.value)) return;
+            const acc = readModalAccount();
+            if (acc) saveAccount(acc);
         };
 
+        async function scanQRIntoModal(e) {
+            const file = e.target.files[0];
+            e.target.value = '';
+            if (!file) return;
+            try {
+                const text = await decodeQRImage(file);
+                if (!editingAccountId && importMigrationFromModal(text)) return;
+                const parsed = text && parseSecretInput(text);
+                if (!parsed) {
+                    alert(i18n[currentLang].qr_not_found);
+                    return;
+                }
+                elements.modalSecret.value = text;
+                if (!elements.modalAccountName.value.trim() && parsed.name) {
+                    elements.modalAccountName.value = parsed.name;
+                }
+            } catch (err) {
+                console.error("QR Scan Error:", err);
+                alert(i18n[currentLang].qr_unsupported);
+            }
+        }
+
+        // No BarcodeDetector (Firefox, Safari) means no image scanning, Google transfer QRs included
+        elements.scanQrBtn.classList.toggle('hidden', !('BarcodeDetector' in window));
+        elements.scanQrBtn.onclick = () => elements.qrInput.click();
+        elements.qrInput.onchange = scanQRIntoModal;
+        elements.generateSecretBtn.onclick = () => {
+            elements.modalSecret.value = generateSecret(parseInt(elements.secretBitsSelect.value, 10));
+        };
+        elements.accountSearch.oninput = renderAccounts;
+        elements.hotpNextBtn.onclick = () => {
+            const acc = activeAccount();
+            if (isHOTP(acc) && accounts.includes(acc)) setHOTPCounter(acc, (acc.counter || 0) + 1);
+        };
         elements.exportBtn.onclick = exportAccounts;
+        elements.exportEncryptedBtn.onclick = exportEncryptedAccounts;
         elements.importBtn.onclick = () => elements.importInput.click();
         elements.deleteAllBtn.onclick = deleteAllAccounts;
         elements.importInput.onchange = importAccounts;
 
         langSelect.onchange = (e) => applyLanguage(e.target.value);
+        elements.formatSelect.onchange = (e) => applyFormat(e.target.value);
         themeToggle.onclick = toggleTheme;
         copyBtn.onclick = copyToClipboard;
         document.getElementById('toggleValidatorBtn').onclick = () => validatorSection.classList.toggle('hidden');
@@ -491,9 +1856,17 @@ const i18n = {
 
         async function shareAccount() {
             const secret = secretInput.value.trim();
-            if (!secret) return;
-            const baseUrl = window.location.href.split('?')[0];
-            const url = `${baseUrl}?secret=${secret}`;
+            const acc = activeAccount();
+            if (!secret || isHOTP(acc) || truncationOf(acc) !== 'standard') return;
+            const baseUrl = window.location.href.split(/[?#]/)[0];
+            // The fragment is never sent to the server, so the secret stays out of access logs and referrers
+            let url = `${baseUrl}#secret=${secret}`;
+            const { digits, period, algorithm } = totpParams(acc);
+            if (digits !== 6) url += `&digits=${digits}`;
+            if (period !== 30) url += `&period=${period}`;
+            if (algorithm !== 'SHA1') url += `&algorithm=${algorithm}`;
+            // The clock correction is ours alone; without it the recipient would see different codes
+            if (acc && acc.offset) url += `&offset=${acc.offset}`;
 
             try {
                 // Open new tab
@@ -504,12 +1877,93 @@ const i18n = {
         }
         elements.shareBtn.onclick = shareAccount;
 
+        function currentKeyURI() {
+            const secret = secretInput.value.trim();
+            if (!secret) return null;
+            const acc = activeAccount();
+            const truncation = truncationOf(acc);
+            if (truncation !== 'standard' && truncation !== 'steam') return null;
+            const { digits, period, algorithm } = totpParams(acc);
+            return buildKeyURI('', acc ? acc.name : 'Shared Account', secret, {
+                algorithm: algorithm !== 'SHA1' ? algorithm : undefined,
+                encoder: truncation === 'steam' ? 'steam' : undefined,
+                digits: digits !== 6 ? digits : undefined,
+                type: isHOTP(acc) ? 'hotp' : undefined,
+                counter: isHOTP(acc) ? acc.counter : undefined,
+                period: period !== 30 ? period : undefined
+            });
+        }
+
+        async function copyKeyURI() {
+            const uri = currentKeyURI();
+            if (!uri) return;
+            try {
+                await navigator.clipboard.writeText(uri);
+                elements.keyUriFeedback.classList.add('show');
+                setTimeout(() => elements.keyUriFeedback.classList.remove('show'), 2000);
+            } catch (err) {
+                console.error('Copy failed', err);
+            }
+        }
+        elements.keyUriBtn.onclick = copyKeyURI;
+
+        function renderKeyQR() {
+            if (elements.qrSection.classList.contains('hidden')) return;
+            const uri = currentKeyURI();
+            const modules = uri && encodeQR(uri);
+            if (modules) {
+                elements.qrImage.src = 'data:image/svg+xml;charset=utf-8,' + encodeURIComponent(qrToSvg(modules));
+            } else {
+                elements.qrImage.removeAttribute('src');
+            }
+        }
+        elements.toggleQrBtn.onclick = () => {
+            elements.qrSection.classList.toggle('hidden');
+            renderKeyQR();
+        };
+
+        // Case-insensitive lookup used by #account= links.
+        function findAccountByName(name) {
+            const wanted = (name || '').trim().toLowerCase();
+            return wanted ? accounts.find(a => (a.name || '').trim().toLowerCase() === wanted) : undefined;
+        }
+
         // Initialization
         const urlParams = new URLSearchParams(window.location.search);
-        const urlSecret = urlParams.get('secret');
+        const hashParams = new URLSearchParams(window.location.hash.slice(1));
+        const parsedUrlSecret = parseSecretInput(hashParams.get('secret') || urlParams.get('secret') || '');
+        const urlSecret = parsedUrlSecret ? parsedUrlSecret.secret : null;
+
+        // Legacy ?secret= links keep working, but move the secret into the fragment. History
+        // still records the rewritten URL; the fragment only keeps the secret out of requests,
+        // server logs and Referer headers from here on.
+        if (urlParams.has('secret') && window.history && window.history.replaceState) {
+            const remaining = new URLSearchParams(urlParams);
+            const fragment = new URLSearchParams();
+            ['secret', 'digits', 'period', 'algorithm', 'offset'].forEach(key => {
+                if (remaining.has(key)) fragment.set(key, remaining.get(key));
+                remaining.delete(key);
+            });
+            hashParams.forEach((value, key) => fragment.set(key, value));
+            const search = remaining.toString();
+            window.history.replaceState(null, '', window.location.pathname + (search ? '?' + search : '') + '#' + fragment.toString());
+        }
 
         if (urlSecret) {
             // SHARE MODE: Minimal UI, no account features
+            const urlParam = (key, isValid) => {
+                const value = parseInt(hashParams.get(key) || urlParams.get(key), 10);
+                return isValid(value) ? value : undefined;
+            };
+            const urlAlgorithm = (hashParams.get('algorithm') || urlParams.get('algorithm') || '').toUpperCase();
+            sharedAccount = {
+                name: 'Shared Account',
+                secret: urlSecret,
+                digits: urlParam('digits', isValidDigits) || parsedUrlSecret.digits,
+                period: urlParam('period', isValidPeriod) || parsedUrlSecret.period,
+                offset: urlParam('offset', isValidOffset) || 0,
+                algorithm: HMAC_ALGORITHMS[urlAlgorithm] ? urlAlgorithm : parsedUrlSecret.algorithm
+            };
             document.getElementById('mainContainer').classList.add('share-mode');
             secretInput.value = urlSecret;
             elements.mainDashboard.classList.remove('hidden');
@@ -519,9 +1973,7 @@ const i18n = {
                 elements.addToDashboardBtn.classList.remove('hidden');
             }
             elements.aboutSection.classList.remove('hidden'); // Show About section
-            fetchTotp();
-            refreshTimer = setInterval(updateProgress, 1000);
-            updateProgress();
+            startRefresh();
             
             if (elements.addToDashboardBtn) {
                 elements.addToDashboardBtn.onclick = () => {
@@ -533,17 +1985,21 @@ const i18n = {
                         elements.modalTitle.textContent = i18n[currentLang].add_to_dashboard;
                         elements.modalAccountName.value = 'Shared Account';
                         elements.modalSecret.value = urlSecret;
+                        elements.modalOffset.value = sharedAccount.offset || 0;
+                        elements.modalExpires.value = '';
+                        elements.modalTags.value = '';
+                        elements.modalDigits.value = sharedAccount.digits || 6;
+                        elements.modalAlphabet.value = '';
                         
                         // We hijack the saveModalBtn behavior specifically for this import flow
                         
                         elements.saveModalBtn.onclick = () => {
-                            const name = elements.modalAccountName.value.trim();
-                            const secret = elements.modalSecret.value.trim();
-                            if (name && secret) {
+                            const acc = readModalAccount();
+                            if (acc) {
                                 // Save it to local storage directly without calling saveAccount (which does UI updates)
                                 // We want to force a reload immediately so the URL params are cleared.
                                 const id = Date.now().toString();
-                                accounts.push({ id, name, secret });
+                                accounts.push({ id, digits: sharedAccount.digits, period: sharedAccount.period, algorithm: sharedAccount.algorithm, ...acc });
                                 localStorage.setItem('totp-accounts', JSON.stringify(accounts));
                                 window.location.href = window.location.pathname; 
                             }
@@ -568,9 +2024,24 @@ const i18n = {
             }
             elements.aboutSection.classList.remove('hidden');
             renderAccounts();
-            if (accounts.length > 0) showAccountTotp(accounts[0]);
+            // #account=<name> opens a saved account directly, e.g. from a bookmark
+            const requested = findAccountByName(hashParams.get('account') || urlParams.get('account'));
+            if (requested) showAccountTotp(requested);
+            else if (accounts.length > 0) showAccountTotp(accounts[0]);
         }
 
+        // Links followed inside an already open tab only change the fragment, so re-read it
+        window.addEventListener('hashchange', () => {
+            const params = new URLSearchParams(window.location.hash.slice(1));
+            if (urlSecret || params.has('secret')) {
+                // Share mode is set up once at load, so entering or leaving it needs a fresh start
+                window.location.reload();
+                return;
+            }
+            const requested = findAccountByName(params.get('account'));
+            if (requested) showAccountTotp(requested);
+        });
+
         // Mouse-drag scroll for account list
         let isDown = false;
         let startX;
@@ -597,6 +2068,7 @@ const i18n = {
         });
 
         applyLanguage(currentLang);
+        applyFormat(currentFormat);
         if (currentTheme === 'light') {
             document.body.classList.add('light-mode');
             document.getElementById('themeIcon').textContent = '☀️';